@@ -1,6 +1,7 @@
 package dns
 
 import (
+	"encoding/binary"
 	"net"
 	"strings"
 
@@ -9,10 +10,44 @@ import (
 )
 
 const (
-	TypeA    uint16 = 1
-	TypeAAAA uint16 = 28
+	TypeA     uint16 = 1
+	TypeNS    uint16 = 2
+	TypeCNAME uint16 = 5
+	TypeMX    uint16 = 15
+	TypeTXT   uint16 = 16
+	TypeAAAA  uint16 = 28
+	TypeSRV   uint16 = 33
+	TypeOPT   uint16 = 41
 )
 
+// ednsOptionCodeEDE is the OPT option-code for Extended DNS Errors, defined
+// by RFC 8914.
+const ednsOptionCodeEDE uint16 = 15
+
+// maxNameCompressionJumps bounds how many RFC 1035 §4.1.4 compression
+// pointers readName will follow for a single name, so a message crafted
+// with a pointer cycle cannot hang the parser.
+const maxNameCompressionJumps = 30
+
+// Config controls the optional parsing behavior of the dns analyzer.
+type Config struct {
+	// ParseExtraRecords enables decoding of AAAA/CNAME/NS/MX/SRV/TXT records
+	// in addition to the default A address lookup.
+	ParseExtraRecords bool
+}
+
+// globalConfig defaults to A-only so this package preserves its existing
+// behavior; embedders opt into AAAA/CNAME/NS/MX/SRV/TXT decoding via
+// SetConfig.
+var globalConfig = &Config{ParseExtraRecords: false}
+
+// SetConfig installs c as the active dns analyzer configuration.
+func SetConfig(c *Config) {
+	if c != nil {
+		globalConfig = c
+	}
+}
+
 func fastfailDnsResponse() protocol.FastFailFn {
 	return func(message *protocol.PayloadMessage) bool {
 		return len(message.Data) <= DNSHeaderSize || len(message.Data) > MaxMessageSize
@@ -36,7 +71,7 @@ Header
  |                    ARCOUNT                    |
  +--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+
 */
-func parseDnsResponse() protocol.ParsePkgFn {
+func parseDnsResponse(transport string) protocol.ParsePkgFn {
 	return func(message *protocol.PayloadMessage) (bool, bool) {
 		offset := message.Offset
 		_, id := message.ReadUInt16(offset)
@@ -44,6 +79,10 @@ func parseDnsResponse() protocol.ParsePkgFn {
 
 		qr := (flags >> 15) & 0x1
 		opcode := (flags >> 11) & 0xf
+		aa := (flags >> 10) & 0x1
+		tc := (flags >> 9) & 0x1
+		rd := (flags >> 8) & 0x1
+		ra := (flags >> 7) & 0x1
 		rcode := flags & 0xf
 
 		_, numOfQuestions := message.ReadUInt16(offset + 4)
@@ -66,9 +105,12 @@ func parseDnsResponse() protocol.ParsePkgFn {
 				3	Name Error
 				4 	Not Implemented
 				5 	Refused
-				6-15 	Reserved for future use.
+				6-15 	Reserved for future use in the base protocol, but combined
+					with an EDNS(0) OPT record's extended-RCODE bits (RFC 6891)
+					to form codes such as 16 (BADVERS), so they aren't rejected
+					here; readAdditionalOpt resolves the actual extended RCODE.
 		*/
-		if qr == 0 || opcode > 2 || rcode > 5 || numOfQuestions == 0 || numOfRR > MaxNumRR {
+		if qr == 0 || opcode > 2 || numOfQuestions == 0 || numOfRR > MaxNumRR {
 			return false, true
 		}
 
@@ -77,32 +119,104 @@ func parseDnsResponse() protocol.ParsePkgFn {
 			return false, true
 		}
 
-		ip := readIpV4Answer(message, numOfAnswers)
+		answers := readAnswers(message, offset, numOfAnswers, globalConfig.ParseExtraRecords)
+		skipResourceRecords(message, offset, numOfAuth)
+		edns := readAdditionalOpt(message, offset, numOfAddl, rcode)
 
 		message.AddStringAttribute(constlabels.DnsDomain, domain)
-		if len(ip) > 0 {
-			message.AddStringAttribute(constlabels.DnsIp, ip)
+		if len(answers.ipv4) > 0 {
+			message.AddStringAttribute(constlabels.DnsIp, strings.Join(answers.ipv4, ","))
+		}
+		if len(answers.ipv6) > 0 {
+			message.AddStringAttribute(constlabels.DnsIpV6, strings.Join(answers.ipv6, ","))
+		}
+		if len(answers.cnames) > 0 {
+			message.AddStringAttribute(constlabels.DnsCname, strings.Join(answers.cnames, ","))
+		}
+		if len(answers.nsNames) > 0 {
+			message.AddStringAttribute(constlabels.DnsNs, strings.Join(answers.nsNames, ","))
+		}
+		if len(answers.mxNames) > 0 {
+			message.AddStringAttribute(constlabels.DnsMx, strings.Join(answers.mxNames, ","))
+		}
+		if len(answers.srvNames) > 0 {
+			message.AddStringAttribute(constlabels.DnsSrv, strings.Join(answers.srvNames, ","))
+		}
+		if len(answers.txts) > 0 {
+			message.AddStringAttribute(constlabels.DnsTxt, strings.Join(answers.txts, ","))
 		}
 		message.AddIntAttribute(constlabels.DnsId, int64(id))
 		message.AddIntAttribute(constlabels.DnsRcode, int64(rcode))
-		if rcode > 0 {
+		message.AddStringAttribute(constlabels.DnsTransport, transport)
+		message.AddBoolAttribute(constlabels.DnsTruncated, tc == 1)
+		if tc == 1 {
+			// DnsTcpRetryId is the DNS-specific half of the correlation key a
+			// follow-up TCP query is stitched to: paired with the four-tuple
+			// the network analyzer already attaches to every message, it lets
+			// that retry be joined back to this truncated response.
+			message.AddIntAttribute(constlabels.DnsTcpRetryId, int64(id))
+		}
+		message.AddBoolAttribute(constlabels.DnsAuthoritative, aa == 1)
+		message.AddBoolAttribute(constlabels.DnsRecursionAvailable, ra == 1)
+		message.AddIntAttribute(constlabels.DnsResponseFlags, int64(flags))
+		if edns.hasOpt {
+			message.AddIntAttribute(constlabels.DnsEdnsExtendedRcode, int64(edns.extendedRcode))
+			if edns.hasEde {
+				message.AddIntAttribute(constlabels.DnsEdeCode, int64(edns.edeCode))
+				message.AddStringAttribute(constlabels.DnsEdeText, edns.edeText)
+			}
+		}
+		if isErr, errType := classifyDnsError(rcode, tc, aa, ra, rd); isErr {
 			message.AddBoolAttribute(constlabels.IsError, true)
-			message.AddIntAttribute(constlabels.ErrorType, int64(constlabels.ProtocolError))
+			message.AddIntAttribute(constlabels.ErrorType, int64(errType))
 		}
 		return true, true
 	}
 }
 
-func readIpV4Answer(message *protocol.PayloadMessage, answerCount uint16) string {
+// classifyDnsError decides whether a response's header flags make it
+// erroneous even when RCODE alone does not, and if so, which ErrorType
+// applies. rcode, tc, aa, ra, and rd are the header fields of the same
+// name, each either 0 or 1 except rcode.
+func classifyDnsError(rcode, tc, aa, ra, rd uint16) (bool, int) {
+	switch {
+	case rcode > 0:
+		return true, constlabels.ProtocolError
+	case tc == 1 || (aa == 0 && ra == 0 && rd == 1):
+		// Truncation forces the client to retry over TCP, and a
+		// non-authoritative resolver reporting RD=1/RA=0 failed to honor
+		// recursion, so both leave the caller without a usable answer
+		// even though RCODE is 0. An authoritative server (AA=1)
+		// legitimately answers RD=1 queries with RA=0, so AA=0 is
+		// required to avoid flagging those as errors.
+		return true, constlabels.DnsFlagError
+	default:
+		return false, constlabels.NoError
+	}
+}
+
+// dnsAnswers collects the attributes extracted from the answer section by
+// readAnswers. A is always decoded; the remaining fields are only populated
+// when parseExtraRecords is enabled.
+type dnsAnswers struct {
+	ipv4     []string
+	ipv6     []string
+	cnames   []string
+	nsNames  []string
+	mxNames  []string
+	srvNames []string
+	txts     []string
+}
+
+func readAnswers(message *protocol.PayloadMessage, msgStart int, answerCount uint16, parseExtraRecords bool) dnsAnswers {
 	var (
 		complete bool
 		aType    uint16
 		length   uint16
 		ip       net.IP
-		ips      []string
+		answers  dnsAnswers
 	)
 
-	ips = make([]string, 0)
 	offset := message.Offset
 	for i := 0; i < int(answerCount); i++ {
 		/*
@@ -126,19 +240,259 @@ func readIpV4Answer(message *protocol.PayloadMessage, answerCount uint16) string
 		}
 
 		offset += 2
-		if aType == TypeA {
+		switch aType {
+		case TypeA:
 			offset, ip = message.ReadBytes(offset, int(length))
 			if ip == nil {
-				break
+				message.Offset = offset
+				return answers
+			}
+			answers.ipv4 = append(answers.ipv4, ip.String())
+			offset += int(length)
+		case TypeAAAA:
+			if parseExtraRecords {
+				offset, ip = message.ReadBytes(offset, int(length))
+				if ip == nil {
+					message.Offset = offset
+					return answers
+				}
+				if ip6 := ip.To16(); ip6 != nil {
+					answers.ipv6 = append(answers.ipv6, ip6.String())
+				}
 			}
-			ips = append(ips, ip.String())
+			offset += int(length)
+		case TypeCNAME, TypeNS, TypeMX, TypeSRV, TypeTXT:
+			if parseExtraRecords {
+				readExtraRecord(message.Data, msgStart, offset, aType, int(length), &answers)
+			}
+			offset += int(length)
+		default:
+			offset += int(length)
+		}
+	}
+	message.Offset = offset
+	return answers
+}
+
+// skipResourceRecords advances message.Offset past count resource records
+// starting at message.Offset, without extracting any attributes from them.
+// It is used to step over the authority section so the additional section
+// can be located.
+func skipResourceRecords(message *protocol.PayloadMessage, msgStart int, count uint16) bool {
+	data := message.Data
+	offset := message.Offset
+	for i := 0; i < int(count); i++ {
+		next, _, ok := readName(data, msgStart, offset)
+		if !ok {
+			message.Offset = offset
+			return false
+		}
+		offset = next
+
+		if offset+10 > len(data) {
+			message.Offset = offset
+			return false
+		}
+		complete, rdlength := message.ReadUInt16(offset + 8)
+		if complete {
+			message.Offset = offset
+			return false
+		}
+		offset += 10 + int(rdlength)
+	}
+	message.Offset = offset
+	return true
+}
+
+// dnsEdns holds the attributes decoded from an EDNS(0) OPT pseudo-RR found
+// in the additional section, per RFC 6891 and RFC 8914.
+type dnsEdns struct {
+	hasOpt        bool
+	extendedRcode uint16
+	hasEde        bool
+	edeCode       uint16
+	edeText       string
+}
+
+// readAdditionalOpt scans count resource records starting at message.Offset,
+// looking for an OPT pseudo-RR (type 41). baseRcode is the 4-bit RCODE
+// already read from the header, which combines with the OPT TTL's upper 8
+// bits to form the 12-bit extended RCODE.
+func readAdditionalOpt(message *protocol.PayloadMessage, msgStart int, count uint16, baseRcode uint16) dnsEdns {
+	var edns dnsEdns
+	data := message.Data
+	offset := message.Offset
+
+	for i := 0; i < int(count); i++ {
+		next, _, ok := readName(data, msgStart, offset)
+		if !ok {
+			break
+		}
+		offset = next
+
+		if offset+10 > len(data) {
+			break
+		}
+		complete, rrType := message.ReadUInt16(offset)
+		if complete {
+			break
+		}
+		complete, rdlength := message.ReadUInt16(offset + 8)
+		if complete {
+			break
+		}
+		ttl := binary.BigEndian.Uint32(data[offset+4 : offset+8])
+		rdataStart := offset + 10
+
+		if rrType == TypeOPT {
+			edns.hasOpt = true
+			edns.extendedRcode = combineExtendedRcode(ttl, baseRcode)
+			readEdnsOptions(data, rdataStart, int(rdlength), &edns)
 		}
-		offset += int(length)
+		offset = rdataStart + int(rdlength)
 	}
 	message.Offset = offset
-	if len(ips) == 0 {
-		return ""
+	return edns
+}
+
+// combineExtendedRcode forms the 12-bit extended RCODE defined by RFC 6891
+// §6.1.3 from an OPT record's TTL field (whose upper 8 bits hold the
+// extended RCODE's high bits) and the 4-bit RCODE already read from the
+// message header.
+func combineExtendedRcode(ttl uint32, baseRcode uint16) uint16 {
+	extendedRcodeHigh := uint16(ttl>>24) & 0xff
+	return extendedRcodeHigh<<4 | (baseRcode & 0xf)
+}
+
+// readEdnsOptions walks the option-code/option-length/option-data triples
+// that make up an OPT record's rdata, extracting the Extended DNS Error
+// (RFC 8914) option when present.
+func readEdnsOptions(data []byte, start, length int, edns *dnsEdns) {
+	end := start + length
+	if end > len(data) {
+		end = len(data)
+	}
+	pos := start
+	for pos+4 <= end {
+		optCode := binary.BigEndian.Uint16(data[pos : pos+2])
+		optLen := int(binary.BigEndian.Uint16(data[pos+2 : pos+4]))
+		pos += 4
+		if pos+optLen > end {
+			break
+		}
+		if optCode == ednsOptionCodeEDE && optLen >= 2 {
+			edns.hasEde = true
+			edns.edeCode = binary.BigEndian.Uint16(data[pos : pos+2])
+			edns.edeText = string(data[pos+2 : pos+optLen])
+		}
+		pos += optLen
+	}
+}
+
+// readExtraRecord decodes the rdata of a single CNAME/NS/MX/SRV/TXT record
+// that starts at offset in data and is length bytes long, appending the
+// decoded value onto answers.
+func readExtraRecord(data []byte, msgStart, offset int, aType uint16, length int, answers *dnsAnswers) {
+	switch aType {
+	case TypeCNAME:
+		if _, name, ok := readName(data, msgStart, offset); ok {
+			answers.cnames = append(answers.cnames, name)
+		}
+	case TypeNS:
+		if _, name, ok := readName(data, msgStart, offset); ok {
+			answers.nsNames = append(answers.nsNames, name)
+		}
+	case TypeMX:
+		// uint16 preference, followed by the exchange domain name.
+		if offset+2 > len(data) {
+			return
+		}
+		if _, name, ok := readName(data, msgStart, offset+2); ok {
+			answers.mxNames = append(answers.mxNames, name)
+		}
+	case TypeSRV:
+		// uint16 priority, uint16 weight, uint16 port, then the target name.
+		if offset+6 > len(data) {
+			return
+		}
+		if _, name, ok := readName(data, msgStart, offset+6); ok {
+			answers.srvNames = append(answers.srvNames, name)
+		}
+	case TypeTXT:
+		if offset+length > len(data) {
+			return
+		}
+		answers.txts = append(answers.txts, readTxt(data[offset:offset+length]))
+	}
+}
+
+// readTxt concatenates the one-or-more length-prefixed character-strings
+// that make up a TXT record's rdata.
+func readTxt(rdata []byte) string {
+	var segments []string
+	pos := 0
+	for pos < len(rdata) {
+		segLen := int(rdata[pos])
+		pos++
+		if pos+segLen > len(rdata) {
+			break
+		}
+		segments = append(segments, string(rdata[pos:pos+segLen]))
+		pos += segLen
 	}
+	return strings.Join(segments, "")
+}
+
+// readName decodes a domain name starting at offset in data, following RFC
+// 1035 §4.1.4 compression pointers (a label length byte with its top two
+// bits set) where present. msgStart is the absolute offset of the DNS
+// message's ID field within data, since pointers are relative to it.
+//
+// It returns the offset immediately after the name as it appears at offset
+// (i.e. not following any pointer jump, so callers reading a fixed-size
+// record can keep advancing correctly), the decoded dotted name, and
+// whether decoding completed without running past the end of data or
+// looping through too many pointer jumps.
+func readName(data []byte, msgStart, offset int) (int, string, bool) {
+	var labels []string
+	pos := offset
+	nextOffset := -1
+	jumps := 0
 
-	return strings.Join(ips, ",")
+	for {
+		if pos < 0 || pos >= len(data) {
+			return offset, "", false
+		}
+		labelLen := int(data[pos])
+		if labelLen == 0 {
+			pos++
+			break
+		}
+		if labelLen&0xc0 == 0xc0 {
+			if pos+1 >= len(data) {
+				return offset, "", false
+			}
+			if nextOffset == -1 {
+				nextOffset = pos + 2
+			}
+			jumps++
+			if jumps > maxNameCompressionJumps {
+				return offset, "", false
+			}
+			pos = msgStart + (int(labelLen&0x3f)<<8 | int(data[pos+1]))
+			continue
+		}
+
+		pos++
+		if pos+labelLen > len(data) {
+			return offset, "", false
+		}
+		labels = append(labels, string(data[pos:pos+labelLen]))
+		pos += labelLen
+	}
+
+	if nextOffset == -1 {
+		nextOffset = pos
+	}
+	return nextOffset, strings.Join(labels, "."), true
 }
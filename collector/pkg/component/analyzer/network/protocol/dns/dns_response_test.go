@@ -0,0 +1,178 @@
+package dns
+
+import (
+	"testing"
+
+	"github.com/Kindling-project/kindling/collector/pkg/model/constlabels"
+)
+
+func TestReadNameSimple(t *testing.T) {
+	data := []byte{3, 'w', 'w', 'w', 7, 'e', 'x', 'a', 'm', 'p', 'l', 'e', 3, 'c', 'o', 'm', 0}
+	next, name, ok := readName(data, 0, 0)
+	if !ok {
+		t.Fatalf("expected ok, got false")
+	}
+	if name != "www.example.com" {
+		t.Fatalf("expected %q, got %q", "www.example.com", name)
+	}
+	if next != len(data) {
+		t.Fatalf("expected offset %d, got %d", len(data), next)
+	}
+}
+
+// TestReadNameCompressionPointer builds a message where the answer's name is
+// a single compression pointer back to the question's name, as real
+// resolvers do to avoid repeating the queried domain in every answer RR.
+func TestReadNameCompressionPointer(t *testing.T) {
+	data := []byte{
+		3, 'w', 'w', 'w', 7, 'e', 'x', 'a', 'm', 'p', 'l', 'e', 3, 'c', 'o', 'm', 0, // offset 0: the name
+		0xc0, 0x00, // offset 17: pointer back to offset 0
+	}
+	next, name, ok := readName(data, 0, 17)
+	if !ok {
+		t.Fatalf("expected ok, got false")
+	}
+	if name != "www.example.com" {
+		t.Fatalf("expected %q, got %q", "www.example.com", name)
+	}
+	// The returned offset is immediately after the pointer itself, not
+	// wherever the pointer jumped to, so a caller reading a fixed-size
+	// record after the name keeps advancing correctly.
+	if next != 19 {
+		t.Fatalf("expected offset 19 after a 2-byte pointer, got %d", next)
+	}
+}
+
+// TestReadNameCompressionCycle constructs a message where a pointer points
+// at another pointer that points back at the first, which would hang a
+// decoder that didn't cap the number of jumps it follows.
+func TestReadNameCompressionCycle(t *testing.T) {
+	data := []byte{
+		0xc0, 0x02, // offset 0: pointer to offset 2
+		0xc0, 0x00, // offset 2: pointer to offset 0
+	}
+	_, _, ok := readName(data, 0, 0)
+	if ok {
+		t.Fatalf("expected a pointer cycle to fail decoding, got ok")
+	}
+}
+
+func TestReadNameTruncated(t *testing.T) {
+	data := []byte{3, 'w', 'w'} // label length 3 but only 2 bytes follow
+	_, _, ok := readName(data, 0, 0)
+	if ok {
+		t.Fatalf("expected truncated name to fail decoding, got ok")
+	}
+}
+
+func TestCombineExtendedRcode(t *testing.T) {
+	tests := []struct {
+		name      string
+		ttl       uint32
+		baseRcode uint16
+		want      uint16
+	}{
+		{"no extension", 0x00000000, 2, 2},
+		// BADVERS is extended RCODE 16, i.e. high byte 1, low nibble 0.
+		{"badvers", 0x01000000, 0, 16},
+		// Extended RCODE high byte combines with a non-zero base RCODE.
+		{"high byte plus base", 0x01000000, 2, 18},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := combineExtendedRcode(tt.ttl, tt.baseRcode); got != tt.want {
+				t.Fatalf("combineExtendedRcode(%#x, %d) = %d, want %d", tt.ttl, tt.baseRcode, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestReadEdnsOptionsExtendedError decodes an OPT rdata carrying a single
+// RFC 8914 Extended DNS Error option, as a resolver attaches when it wants
+// to explain a SERVFAIL (e.g. "DNSSEC Bogus", INFO-CODE 6).
+func TestReadEdnsOptionsExtendedError(t *testing.T) {
+	rdata := []byte{
+		0x00, 0x0f, // option-code 15 (EDE)
+		0x00, 0x09, // option-length 9
+		0x00, 0x06, // INFO-CODE 6 (DNSSEC Bogus)
+		'b', 'o', 'g', 'u', 's', '!', '!', // EXTRA-TEXT
+	}
+	var edns dnsEdns
+	readEdnsOptions(rdata, 0, len(rdata), &edns)
+	if !edns.hasEde {
+		t.Fatalf("expected hasEde to be true")
+	}
+	if edns.edeCode != 6 {
+		t.Fatalf("expected edeCode 6, got %d", edns.edeCode)
+	}
+	if edns.edeText != "bogus!!" {
+		t.Fatalf("expected edeText %q, got %q", "bogus!!", edns.edeText)
+	}
+}
+
+func TestReadEdnsOptionsIgnoresUnknownOption(t *testing.T) {
+	rdata := []byte{
+		0x00, 0x08, // option-code 8 (client subnet, not EDE)
+		0x00, 0x04,
+		0x01, 0x02, 0x03, 0x04,
+	}
+	var edns dnsEdns
+	readEdnsOptions(rdata, 0, len(rdata), &edns)
+	if edns.hasEde {
+		t.Fatalf("expected hasEde to stay false for a non-EDE option")
+	}
+}
+
+func TestClassifyDnsError(t *testing.T) {
+	tests := []struct {
+		name                  string
+		rcode, tc, aa, ra, rd uint16
+		wantErr               bool
+		wantType              int
+	}{
+		{"no error", 0, 0, 1, 1, 1, false, 0},
+		{"servfail", 2, 0, 1, 1, 1, true, constlabels.ProtocolError},
+		{"truncated", 0, 1, 1, 1, 1, true, constlabels.DnsFlagError},
+		{"recursion not honored", 0, 0, 0, 0, 1, true, constlabels.DnsFlagError},
+		{"authoritative RA=0 is fine", 0, 0, 1, 0, 1, false, 0},
+		{"rcode wins over flags", 3, 1, 0, 0, 1, true, constlabels.ProtocolError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotErr, gotType := classifyDnsError(tt.rcode, tt.tc, tt.aa, tt.ra, tt.rd)
+			if gotErr != tt.wantErr {
+				t.Fatalf("isError = %v, want %v", gotErr, tt.wantErr)
+			}
+			if gotErr && gotType != tt.wantType {
+				t.Fatalf("errorType = %d, want %d", gotType, tt.wantType)
+			}
+		})
+	}
+}
+
+func TestReadTxt(t *testing.T) {
+	rdata := []byte{5, 'h', 'e', 'l', 'l', 'o', 5, 'w', 'o', 'r', 'l', 'd'}
+	got := readTxt(rdata)
+	if got != "helloworld" {
+		t.Fatalf("expected %q, got %q", "helloworld", got)
+	}
+}
+
+func TestReadExtraRecordCname(t *testing.T) {
+	data := []byte{3, 'f', 'o', 'o', 3, 'c', 'o', 'm', 0}
+	var answers dnsAnswers
+	readExtraRecord(data, 0, 0, TypeCNAME, len(data), &answers)
+	if len(answers.cnames) != 1 || answers.cnames[0] != "foo.com" {
+		t.Fatalf("expected cnames [%q], got %v", "foo.com", answers.cnames)
+	}
+}
+
+func TestReadExtraRecordMx(t *testing.T) {
+	// uint16 preference (10) followed by the exchange domain name.
+	data := []byte{0x00, 0x0a, 4, 'm', 'a', 'i', 'l', 3, 'c', 'o', 'm', 0}
+	var answers dnsAnswers
+	readExtraRecord(data, 0, 0, TypeMX, len(data), &answers)
+	if len(answers.mxNames) != 1 || answers.mxNames[0] != "mail.com" {
+		t.Fatalf("expected mxNames [%q], got %v", "mail.com", answers.mxNames)
+	}
+}
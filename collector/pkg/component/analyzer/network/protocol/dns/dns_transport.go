@@ -0,0 +1,147 @@
+package dns
+
+import (
+	"encoding/base64"
+	"strings"
+
+	"github.com/Kindling-project/kindling/collector/pkg/component/analyzer/network/protocol"
+)
+
+const (
+	TransportUdp = "udp"
+	TransportTcp = "tcp"
+	TransportDot = "dot"
+	TransportDoh = "doh"
+
+	// PortDot is the IANA-assigned port for DNS-over-TLS (RFC 7858).
+	PortDot = 853
+
+	// DohContentType is the media type RFC 8484 requires for both the POST
+	// body and the Accept/Content-Type headers of a DoH exchange.
+	DohContentType = "application/dns-message"
+
+	// DohQueryParam is the query parameter RFC 8484 §6 defines for the GET
+	// form of a DoH request, holding the base64url(no padding)-encoded
+	// message.
+	DohQueryParam = "dns"
+)
+
+// dotLengthPrefixSize is the 2-byte big-endian length prefix RFC 7858
+// carries over from classic DNS-over-TCP (RFC 1035 §4.2.2) in front of
+// every message.
+const dotLengthPrefixSize = 2
+
+// fastfailDotResponse rejects DoT segments that are too short to hold their
+// length prefix and a DNS header, or that exceed the message size cap.
+func fastfailDotResponse() protocol.FastFailFn {
+	return func(message *protocol.PayloadMessage) bool {
+		return len(message.Data) <= dotLengthPrefixSize+DNSHeaderSize || len(message.Data) > MaxMessageSize
+	}
+}
+
+// parseDotResponse strips the RFC 7858 length prefix off a DNS-over-TLS
+// segment and reuses parseDnsResponse to decode the message it carries.
+func parseDotResponse() protocol.ParsePkgFn {
+	parseResponse := parseDnsResponse(TransportDot)
+	return func(message *protocol.PayloadMessage) (bool, bool) {
+		message.Offset += dotLengthPrefixSize
+		return parseResponse(message)
+	}
+}
+
+// fastfailDohResponse rejects DoH message bodies that are too short to hold
+// a DNS header or that exceed the message size cap.
+func fastfailDohResponse() protocol.FastFailFn {
+	return func(message *protocol.PayloadMessage) bool {
+		return len(message.Data) <= DNSHeaderSize || len(message.Data) > MaxMessageSize
+	}
+}
+
+// parseDohResponse reuses parseDnsResponse to decode a DoH (RFC 8484)
+// message. message.Data must already hold the raw wire-format DNS message,
+// i.e. the HTTP POST body, or the GET form's `dns` query parameter after
+// DecodeDohQueryParam has been applied to it.
+func parseDohResponse() protocol.ParsePkgFn {
+	return parseDnsResponse(TransportDoh)
+}
+
+// IsDohRequest reports whether an HTTP request/response pair carries DoH
+// traffic per RFC 8484: either verb with a matching Content-Type header, or
+// a GET whose path carries the `dns` query parameter.
+func IsDohRequest(contentType string, rawQuery string) bool {
+	if strings.HasPrefix(contentType, DohContentType) {
+		return true
+	}
+	return hasDohQueryParam(rawQuery)
+}
+
+func hasDohQueryParam(rawQuery string) bool {
+	for _, kv := range strings.Split(rawQuery, "&") {
+		if key, _, found := strings.Cut(kv, "="); found && key == DohQueryParam {
+			return true
+		}
+	}
+	return false
+}
+
+// DecodeDohQueryParam extracts and base64url(no padding)-decodes the `dns`
+// query parameter used by the DoH GET form (RFC 8484 §6), returning the raw
+// wire-format DNS message it carries.
+func DecodeDohQueryParam(rawQuery string) ([]byte, error) {
+	value := rawQuery
+	for _, kv := range strings.Split(rawQuery, "&") {
+		if key, v, found := strings.Cut(kv, "="); found && key == DohQueryParam {
+			value = v
+			break
+		}
+	}
+	return base64.RawURLEncoding.DecodeString(value)
+}
+
+// Parser bundles the fast-fail and parse functions the network analyzer
+// registers for a DNS transport.
+type Parser struct {
+	FastFail protocol.FastFailFn
+	Parse    protocol.ParsePkgFn
+}
+
+// NewUdpDnsParser returns the parser registered for UDP traffic on port 53.
+func NewUdpDnsParser() Parser {
+	return Parser{FastFail: fastfailDnsResponse(), Parse: parseDnsResponse(TransportUdp)}
+}
+
+// NewTcpDnsParser returns the parser registered for TCP traffic on port 53.
+func NewTcpDnsParser() Parser {
+	return Parser{FastFail: fastfailDnsResponse(), Parse: parseDnsResponse(TransportTcp)}
+}
+
+// NewDotDnsParser returns the parser registered for TCP traffic on PortDot,
+// DNS-over-TLS's IANA-assigned port (RFC 7858).
+func NewDotDnsParser() Parser {
+	return Parser{FastFail: fastfailDotResponse(), Parse: parseDotResponse()}
+}
+
+// NewDohDnsParser returns the parser registered for HTTP traffic that
+// MatchHTTPDnsRequest has identified as DoH (RFC 8484), rather than against
+// a fixed port the way NewUdpDnsParser/NewTcpDnsParser/NewDotDnsParser are.
+func NewDohDnsParser() Parser {
+	return Parser{FastFail: fastfailDohResponse(), Parse: parseDohResponse()}
+}
+
+// MatchHTTPDnsRequest decides whether an HTTP request/response pair carries
+// DoH traffic and, if so, returns the raw wire-format DNS message to feed
+// into NewDohDnsParser's Parse function: the HTTP body itself for a POST,
+// or the decoded `dns` query parameter for a GET.
+func MatchHTTPDnsRequest(contentType, rawQuery string, body []byte) ([]byte, bool) {
+	if !IsDohRequest(contentType, rawQuery) {
+		return nil, false
+	}
+	if hasDohQueryParam(rawQuery) {
+		data, err := DecodeDohQueryParam(rawQuery)
+		if err != nil {
+			return nil, false
+		}
+		return data, true
+	}
+	return body, true
+}
@@ -0,0 +1,68 @@
+package dns
+
+import (
+	"encoding/base64"
+	"testing"
+)
+
+func TestIsDohRequestContentType(t *testing.T) {
+	if !IsDohRequest("application/dns-message", "") {
+		t.Fatalf("expected application/dns-message content-type to be recognized as DoH")
+	}
+	if IsDohRequest("application/json", "") {
+		t.Fatalf("expected an unrelated content-type to not be recognized as DoH")
+	}
+}
+
+func TestIsDohRequestQueryParam(t *testing.T) {
+	if !IsDohRequest("", "name=example.com&dns=AAABAAABAAAAAAAA") {
+		t.Fatalf("expected a dns= query param to be recognized as DoH")
+	}
+	if IsDohRequest("", "name=example.com") {
+		t.Fatalf("expected a query string without dns= to not be recognized as DoH")
+	}
+}
+
+func TestDecodeDohQueryParam(t *testing.T) {
+	msg := []byte{0xab, 0xcd, 0x01, 0x00, 0x00, 0x01}
+	encoded := base64.RawURLEncoding.EncodeToString(msg)
+
+	got, err := DecodeDohQueryParam("name=example.com&dns=" + encoded)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(got) != string(msg) {
+		t.Fatalf("expected decoded message %x, got %x", msg, got)
+	}
+}
+
+func TestMatchHTTPDnsRequestPost(t *testing.T) {
+	body := []byte{0x01, 0x02, 0x03}
+	data, ok := MatchHTTPDnsRequest("application/dns-message", "", body)
+	if !ok {
+		t.Fatalf("expected a POST with the DoH content-type to match")
+	}
+	if string(data) != string(body) {
+		t.Fatalf("expected the POST body to be returned as-is")
+	}
+}
+
+func TestMatchHTTPDnsRequestGet(t *testing.T) {
+	msg := []byte{0xde, 0xad, 0xbe, 0xef}
+	encoded := base64.RawURLEncoding.EncodeToString(msg)
+
+	data, ok := MatchHTTPDnsRequest("", "dns="+encoded, nil)
+	if !ok {
+		t.Fatalf("expected a GET with a dns= query param to match")
+	}
+	if string(data) != string(msg) {
+		t.Fatalf("expected decoded message %x, got %x", msg, data)
+	}
+}
+
+func TestMatchHTTPDnsRequestNotDoh(t *testing.T) {
+	_, ok := MatchHTTPDnsRequest("text/plain", "", []byte("not dns"))
+	if ok {
+		t.Fatalf("expected non-DoH traffic to not match")
+	}
+}
@@ -1,5 +1,10 @@
 package dubbo
 
+import (
+	"strings"
+	"unicode/utf8"
+)
+
 const (
 	JsonNextLine = byte(0x0a)
 	JsonQutoes   = byte(0x22)
@@ -7,6 +12,16 @@ const (
 
 	SerialHessian2 = byte(0x02)
 	SerialFastjson = byte(0x06)
+	SerialKryo     = byte(0x08)
+	SerialProtobuf = byte(0x16)
+
+	// hessianLongStringChunkTag ('R') marks a non-final chunk of a string
+	// longer than 32768 characters; it is followed by a 16-bit big-endian
+	// character count and more chunks.
+	hessianLongStringChunkTag = byte(0x52)
+	// hessianLongStringFinalTag ('S') marks the final (or only) chunk of a
+	// 16-bit-length-prefixed string.
+	hessianLongStringFinalTag = byte(0x53)
 )
 
 type dubboSerializer interface {
@@ -17,72 +32,127 @@ type dubboSerializer interface {
 	getStringValueByKey(data []byte, offset int, key string) string
 }
 
-var (
-	serialHessian2  = &dubboHessian{}
-	serialFastjson  = &dubboFastJson{}
-	serialUnsupport = &dubboUnsupport{}
-)
+var serialUnsupport = &dubboUnsupport{}
+
+// serializerRegistry maps a Dubbo serialization ID (the byte carried in the
+// request/response header) to the dubboSerializer that knows how to scan
+// its wire format for strings. New serializers are added via Register
+// rather than growing a switch in GetSerializer.
+var serializerRegistry = map[byte]dubboSerializer{
+	SerialHessian2: &dubboHessian{},
+	SerialFastjson: &dubboFastJson{},
+	SerialKryo:     &dubboKryo{},
+	SerialProtobuf: &dubboProtobuf{},
+}
+
+// Register installs s as the dubboSerializer used for payloads whose
+// serialization ID is id, overriding any serializer previously registered
+// for that ID.
+func Register(id byte, s dubboSerializer) {
+	serializerRegistry[id] = s
+}
 
 func GetSerializer(serialID byte) dubboSerializer {
-	switch serialID {
-	case SerialHessian2:
-		return serialHessian2
-	case SerialFastjson:
-		return serialFastjson
-	default:
-		return serialUnsupport
+	if s, ok := serializerRegistry[serialID]; ok {
+		return s
 	}
+	return serialUnsupport
 }
 
 type dubboHessian struct{}
 
 func (dh *dubboHessian) eatString(data []byte, offset int) int {
 	dataLength := len(data)
-	if offset >= dataLength {
+	if offset < 0 || offset >= dataLength {
 		return dataLength
 	}
 
 	tag := data[offset]
-	if tag >= 0x30 && tag <= 0x33 {
+	switch {
+	case tag == hessianLongStringChunkTag || tag == hessianLongStringFinalTag:
+		next, _ := dh.readChunkedString(data, offset)
+		return next
+	case tag >= 0x30 && tag <= 0x33:
 		if offset+1 == dataLength {
 			return dataLength
 		}
 		// [x30-x34] <utf8-data>
-		return offset + 2 + int(tag-0x30)<<8 + int(data[offset+1])
-	} else {
-		return offset + 1 + int(tag)
+		charLength := int(tag-0x30)<<8 + int(data[offset+1])
+		return utf8CharsByteEnd(data, offset+2, charLength)
+	default:
+		return utf8CharsByteEnd(data, offset+1, int(tag))
 	}
 }
 
 func (dh *dubboHessian) getStringValue(data []byte, offset int) (int, string) {
 	dataLength := len(data)
-	if offset >= dataLength {
+	if offset < 0 || offset >= dataLength {
 		return dataLength, ""
 	}
 
-	var stringValueLength int
 	tag := data[offset]
-	if tag >= 0x30 && tag <= 0x33 {
+	switch {
+	case tag == hessianLongStringChunkTag || tag == hessianLongStringFinalTag:
+		return dh.readChunkedString(data, offset)
+	case tag >= 0x30 && tag <= 0x33:
 		if offset+1 == dataLength {
 			return dataLength, ""
 		}
 		// [x30-x34] <utf8-data>
-		stringValueLength = int(tag-0x30)<<8 + int(data[offset+1])
-		offset += 2
-	} else {
-		stringValueLength = int(tag)
-		offset += 1
+		charLength := int(tag-0x30)<<8 + int(data[offset+1])
+		return dh.readFixedString(data, offset+2, charLength)
+	default:
+		return dh.readFixedString(data, offset+1, int(tag))
 	}
+}
 
-	if offset+stringValueLength >= len(data) {
+// readFixedString reads charLength UTF-8 characters starting at offset.
+// Hessian2 string lengths are counts of characters, not bytes, so a
+// multi-byte rune must only advance the returned offset by its own byte
+// width, not by one byte per character.
+func (dh *dubboHessian) readFixedString(data []byte, offset, charLength int) (int, string) {
+	dataLength := len(data)
+	if offset < 0 || offset >= dataLength {
+		return dataLength, ""
+	}
+	end := utf8CharsByteEnd(data, offset, charLength)
+	if end >= dataLength {
 		return dataLength, string(data[offset:])
 	}
-	return offset + stringValueLength, string(data[offset : offset+stringValueLength])
+	return end, string(data[offset:end])
+}
+
+// readChunkedString decodes zero or more x52 (non-final) chunks followed by
+// a terminating x53 (final) chunk, concatenating their content. Hessian2
+// falls back to this chunked form for strings over 32768 characters.
+func (dh *dubboHessian) readChunkedString(data []byte, offset int) (int, string) {
+	dataLength := len(data)
+	var result strings.Builder
+	pos := offset
+	for {
+		if pos >= dataLength || pos+3 > dataLength {
+			return dataLength, result.String()
+		}
+		tag := data[pos]
+		if tag != hessianLongStringChunkTag && tag != hessianLongStringFinalTag {
+			return pos, result.String()
+		}
+		charLength := int(data[pos+1])<<8 | int(data[pos+2])
+		next, chunk := dh.readFixedString(data, pos+3, charLength)
+		result.WriteString(chunk)
+		pos = next
+		if tag == hessianLongStringFinalTag {
+			return pos, result.String()
+		}
+	}
 }
 
 func (dh *dubboHessian) getStringValueByKey(data []byte, from int, key string) string {
 	keyLength := len(key)
 	dataLength := len(data)
+	if keyLength == 0 || dataLength == 0 {
+		return ""
+	}
 	firstKeyword := byte(key[0])
 
 	for i := from; i < dataLength; i++ {
@@ -97,6 +167,20 @@ func (dh *dubboHessian) getStringValueByKey(data []byte, from int, key string) s
 	return ""
 }
 
+// utf8CharsByteEnd returns the byte offset reached after walking charCount
+// UTF-8 characters forward from offset.
+func utf8CharsByteEnd(data []byte, offset, charCount int) int {
+	pos := offset
+	for i := 0; i < charCount && pos < len(data); i++ {
+		_, size := utf8.DecodeRune(data[pos:])
+		if size == 0 {
+			size = 1
+		}
+		pos += size
+	}
+	return pos
+}
+
 func (dh *dubboHessian) getStrValue(data []byte, dataLength int, index int, length int) string {
 	if index >= dataLength {
 		return ""
@@ -210,3 +294,155 @@ func (unsupport *dubboUnsupport) getStringValue(data []byte, offset int) (int, s
 func (unsupport *dubboUnsupport) getStringValueByKey(data []byte, offset int, key string) string {
 	return ""
 }
+
+// dubboProtobuf scans Protobuf-encoded payloads (serial ID 22), where
+// string fields are written as a varint length followed by the UTF-8
+// bytes, per the protobuf wire format's length-delimited (type 2) fields.
+type dubboProtobuf struct{}
+
+// readVarint decodes a protobuf base-128 varint starting at offset,
+// returning the offset just past it and the decoded value.
+func (pb *dubboProtobuf) readVarint(data []byte, offset int) (int, uint64) {
+	var value uint64
+	pos := offset
+	for shift := 0; pos < len(data) && shift < 64; shift += 7 {
+		b := data[pos]
+		pos++
+		value |= uint64(b&0x7f) << shift
+		if b&0x80 == 0 {
+			break
+		}
+	}
+	return pos, value
+}
+
+func (pb *dubboProtobuf) eatString(data []byte, offset int) int {
+	dataLength := len(data)
+	if offset >= dataLength {
+		return dataLength
+	}
+
+	valueOffset, length := pb.readVarint(data, offset)
+	end := valueOffset + int(length)
+	if end < valueOffset || end > dataLength {
+		return dataLength
+	}
+	return end
+}
+
+func (pb *dubboProtobuf) getStringValue(data []byte, offset int) (int, string) {
+	dataLength := len(data)
+	if offset >= dataLength {
+		return dataLength, ""
+	}
+
+	valueOffset, length := pb.readVarint(data, offset)
+	end := valueOffset + int(length)
+	if end < valueOffset || end > dataLength {
+		return dataLength, string(data[valueOffset:])
+	}
+	return end, string(data[valueOffset:end])
+}
+
+func (pb *dubboProtobuf) getStringValueByKey(data []byte, from int, key string) string {
+	keyLength := len(key)
+	dataLength := len(data)
+	if keyLength == 0 || dataLength == 0 {
+		return ""
+	}
+	firstKeyword := byte(key[0])
+
+	for i := from; i < dataLength; i++ {
+		if data[i] != firstKeyword || i+keyLength > dataLength {
+			continue
+		}
+		if string(data[i:i+keyLength]) == key {
+			_, value := pb.getStringValue(data, i+keyLength)
+			return value
+		}
+	}
+	return ""
+}
+
+// dubboKryo scans Kryo-encoded payloads (serial ID 8). Kryo's default
+// String serializer writes a variable-length int prefix using the same
+// base-128 continuation-bit scheme as protobuf, but the decoded value is
+// charCount+1 (0 means null, 1 means empty), not a raw byte length; Kryo
+// also has a separate high-bit-terminated inline encoding it uses for
+// pure-ASCII strings, which carries no length prefix at all. This scanner
+// only understands the length-prefixed form, so it is a best-effort
+// heuristic rather than a full Kryo decoder: the first byte of a pure-ASCII
+// string is read as a length prefix, so its value is not decoded correctly.
+type dubboKryo struct{}
+
+// readVarInt decodes a Kryo variable-length int starting at offset,
+// returning the offset just past it and the decoded value.
+func (kr *dubboKryo) readVarInt(data []byte, offset int) (int, int) {
+	var value int
+	pos := offset
+	for shift := 0; pos < len(data) && shift < 35; shift += 7 {
+		b := data[pos]
+		pos++
+		value |= int(b&0x7f) << shift
+		if b&0x80 == 0 {
+			break
+		}
+	}
+	return pos, value
+}
+
+func (kr *dubboKryo) eatString(data []byte, offset int) int {
+	dataLength := len(data)
+	if offset >= dataLength {
+		return dataLength
+	}
+
+	valueOffset, n := kr.readVarInt(data, offset)
+	if n <= 1 {
+		// n==0 is null, n==1 is the empty string; neither has inline bytes.
+		return valueOffset
+	}
+	end := utf8CharsByteEnd(data, valueOffset, n-1)
+	if end > dataLength {
+		return dataLength
+	}
+	return end
+}
+
+func (kr *dubboKryo) getStringValue(data []byte, offset int) (int, string) {
+	dataLength := len(data)
+	if offset >= dataLength {
+		return dataLength, ""
+	}
+
+	valueOffset, n := kr.readVarInt(data, offset)
+	if n <= 1 {
+		// n==0 is null, n==1 is the empty string.
+		return valueOffset, ""
+	}
+	end := utf8CharsByteEnd(data, valueOffset, n-1)
+	if end > dataLength {
+		return dataLength, string(data[valueOffset:])
+	}
+	return end, string(data[valueOffset:end])
+}
+
+func (kr *dubboKryo) getStringValueByKey(data []byte, from int, key string) string {
+	keyLength := len(key)
+	dataLength := len(data)
+	if keyLength == 0 || dataLength == 0 {
+		return ""
+	}
+	firstKeyword := byte(key[0])
+
+	for i := from; i < dataLength; i++ {
+		if data[i] != firstKeyword || i+keyLength > dataLength {
+			continue
+		}
+		if string(data[i:i+keyLength]) == key {
+			_, value := kr.getStringValue(data, i+keyLength)
+			return value
+		}
+	}
+	return ""
+}
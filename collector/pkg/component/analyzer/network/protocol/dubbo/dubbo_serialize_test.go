@@ -0,0 +1,128 @@
+package dubbo
+
+import "testing"
+
+// shortString encodes s as a Hessian2 short string (tag 0x00-0x1f, equal to
+// the character count) followed by its UTF-8 bytes, the form dubbo-go uses
+// for every attachment key and for most attachment values such as "path",
+// "interface" and "version".
+func shortString(s string) []byte {
+	return append([]byte{byte(len(s))}, []byte(s)...)
+}
+
+// dubboGoAttachments reproduces the Hessian2 bytes dubbo-go's generic filter
+// writes for a typical invocation's attachment map: path/interface/version/
+// timeout, each value encoded as its own short string. Hessian2's x51 ref
+// tag only ever refers back to a composite value (list/map/object), never a
+// bare string, so dubbo-go always writes attachment string values inline
+// even when two of them happen to be equal.
+func dubboGoAttachments() []byte {
+	var data []byte
+	data = append(data, shortString("path")...)
+	data = append(data, shortString("com.dubbo.UserProvider")...)
+	data = append(data, shortString("interface")...)
+	data = append(data, shortString("com.dubbo.UserProvider")...)
+	data = append(data, shortString("version")...)
+	data = append(data, shortString("1.0")...)
+	data = append(data, shortString("timeout")...)
+	data = append(data, shortString("3000")...)
+	return data
+}
+
+// dubboGoChunkedAttachment reproduces the x52/x53 chunked form Hessian2
+// falls back to for an attachment value that doesn't fit in a short string,
+// such as a long "group" tag.
+func dubboGoChunkedAttachment() []byte {
+	var data []byte
+	data = append(data, shortString("group")...)
+	data = append(data, hessianLongStringChunkTag, 0x00, 0x03, 's', 'v', 'c')
+	data = append(data, hessianLongStringFinalTag, 0x00, 0x02, '-', 'a')
+	return data
+}
+
+// TestDubboHessianGetStringValueByKeyAttachments checks the decoded value
+// for every key in the dubbo-go-shaped attachment fixture above.
+func TestDubboHessianGetStringValueByKeyAttachments(t *testing.T) {
+	data := dubboGoAttachments()
+	serializer := &dubboHessian{}
+	cases := map[string]string{
+		"path":      "com.dubbo.UserProvider",
+		"interface": "com.dubbo.UserProvider",
+		"version":   "1.0",
+		"timeout":   "3000",
+	}
+	for key, want := range cases {
+		if got := serializer.getStringValueByKey(data, 0, key); got != want {
+			t.Errorf("getStringValueByKey(%q) = %q, want %q", key, got, want)
+		}
+	}
+}
+
+func TestDubboHessianChunkedAttachment(t *testing.T) {
+	serializer := &dubboHessian{}
+	data := dubboGoChunkedAttachment()
+	if got := serializer.getStringValueByKey(data, 0, "group"); got != "svc-a" {
+		t.Fatalf("getStringValueByKey(%q) = %q, want %q", "group", got, "svc-a")
+	}
+}
+
+func TestDubboHessianChunkedString(t *testing.T) {
+	serializer := &dubboHessian{}
+	data := []byte{
+		hessianLongStringChunkTag, 0x00, 0x02, 'h', 'i',
+		hessianLongStringFinalTag, 0x00, 0x01, '!',
+	}
+	next, value := serializer.getStringValue(data, 0)
+	if value != "hi!" {
+		t.Fatalf("expected chunked string %q, got %q", "hi!", value)
+	}
+	if next != len(data) {
+		t.Fatalf("expected offset %d after chunked string, got %d", len(data), next)
+	}
+}
+
+// FuzzDubboHessianGetStringValueByKey is seeded with the dubbo-go attachment
+// fixtures above and checks a property that must hold for every input,
+// not just the seeds: the call must not panic and must be deterministic.
+// Exact decoded values for these fixtures are checked separately by
+// TestDubboHessianGetStringValueByKeyAttachments and
+// TestDubboHessianChunkedAttachment, since a fixed expected value asserted
+// inside the fuzz body would not hold for the inputs go test -fuzz mutates
+// from these seeds, so every mutated input would be flagged as a failure.
+func FuzzDubboHessianGetStringValueByKey(f *testing.F) {
+	f.Add(dubboGoAttachments(), "path")
+	f.Add(dubboGoAttachments(), "interface")
+	f.Add(dubboGoAttachments(), "version")
+	f.Add(dubboGoAttachments(), "timeout")
+	f.Add(dubboGoChunkedAttachment(), "group")
+
+	serializer := &dubboHessian{}
+	f.Fuzz(func(t *testing.T, data []byte, key string) {
+		got := serializer.getStringValueByKey(data, 0, key)
+		if again := serializer.getStringValueByKey(data, 0, key); again != got {
+			t.Errorf("getStringValueByKey(%q) is non-deterministic: %q then %q", key, got, again)
+		}
+	})
+}
+
+// FuzzDubboHessianGetStringValue is seeded with individual Hessian2 string
+// encodings pulled from the dubbo-go attachment fixtures above, checking the
+// same not-panicking and deterministic properties, plus that the returned
+// next-offset never runs past the end of data. Exact values are checked by
+// TestDubboHessianChunkedString for the same reason given above.
+func FuzzDubboHessianGetStringValue(f *testing.F) {
+	f.Add(shortString("com.dubbo.UserProvider"), 0)
+	f.Add([]byte{0x30, 0x02, 'h', 'i'}, 0)
+	f.Add(dubboGoChunkedAttachment(), 6)
+
+	serializer := &dubboHessian{}
+	f.Fuzz(func(t *testing.T, data []byte, offset int) {
+		next, got := serializer.getStringValue(data, offset)
+		if again, gotAgain := serializer.getStringValue(data, offset); again != next || gotAgain != got {
+			t.Errorf("getStringValue() is non-deterministic: (%d, %q) then (%d, %q)", next, got, again, gotAgain)
+		}
+		if next > len(data) {
+			t.Errorf("getStringValue() next = %d, past len(data) = %d", next, len(data))
+		}
+	})
+}
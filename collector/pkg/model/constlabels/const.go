@@ -0,0 +1,28 @@
+package constlabels
+
+// IsError and ErrorType are attached to a message by any protocol analyzer
+// that can classify it as erroneous, to let downstream consumers filter or
+// aggregate on error status without understanding every protocol's own
+// error representation.
+const (
+	IsError   = "is_error"
+	ErrorType = "error_type"
+)
+
+// ErrorType values. Most protocol analyzers only ever set a handful of
+// these; an analyzer that needs a protocol-specific reason unrepresented
+// here should add a new value rather than overloading an existing one.
+const (
+	NoError = iota
+	ConnectFail
+	DisconnectFail
+	Timeout
+	UnknownError
+	ProtocolError
+
+	// DnsFlagError marks a DNS response that RCODE alone doesn't flag as
+	// erroneous but whose header flags leave the caller without a usable
+	// answer anyway, e.g. a truncated (TC=1) response or a non-authoritative
+	// resolver reporting RD=1/RA=0.
+	DnsFlagError
+)
@@ -0,0 +1,58 @@
+package constlabels
+
+// DNS attribute keys, attached to a message parsed by the dns protocol
+// analyzer.
+const (
+	DnsId     = "dns_id"
+	DnsDomain = "dns_domain"
+	DnsRcode  = "dns_rcode"
+	DnsIp     = "dns_ip"
+
+	// DnsIpV6 holds the comma-joined AAAA answers, analogous to DnsIp for A
+	// records.
+	DnsIpV6 = "dns_ip_v6"
+	// DnsCname holds the comma-joined CNAME answers.
+	DnsCname = "dns_cname"
+	// DnsNs holds the comma-joined NS answers.
+	DnsNs = "dns_ns"
+	// DnsMx holds the comma-joined MX exchange domains.
+	DnsMx = "dns_mx"
+	// DnsSrv holds the comma-joined SRV target domains.
+	DnsSrv = "dns_srv"
+	// DnsTxt holds the comma-joined TXT answers.
+	DnsTxt = "dns_txt"
+
+	// DnsEdnsExtendedRcode holds the 12-bit RCODE formed by combining the
+	// header's 4-bit RCODE with an EDNS(0) OPT record's extended-RCODE bits
+	// (RFC 6891), when the response carries an OPT record.
+	DnsEdnsExtendedRcode = "dns_edns_extended_rcode"
+	// DnsEdeCode holds the INFO-CODE of an Extended DNS Error option (RFC
+	// 8914), when present.
+	DnsEdeCode = "dns_ede_code"
+	// DnsEdeText holds the EXTRA-TEXT of an Extended DNS Error option (RFC
+	// 8914), when present.
+	DnsEdeText = "dns_ede_text"
+
+	// DnsTransport holds which transport (udp/tcp/dot/doh) carried the
+	// message.
+	DnsTransport = "dns_transport"
+
+	// DnsTruncated reports the header's TC bit: the response was too large
+	// for its transport and got truncated.
+	DnsTruncated = "dns_truncated"
+	// DnsAuthoritative reports the header's AA bit: the responder is
+	// authoritative for the queried domain.
+	DnsAuthoritative = "dns_authoritative"
+	// DnsRecursionAvailable reports the header's RA bit: the responder
+	// supports recursive queries.
+	DnsRecursionAvailable = "dns_recursion_available"
+	// DnsResponseFlags holds the raw 16-bit header flags word, for
+	// consumers that need bits this analyzer doesn't break out individually.
+	DnsResponseFlags = "dns_response_flags"
+	// DnsTcpRetryId holds the DNS-specific half of the correlation key a
+	// follow-up TCP query is stitched to after a truncated (TC=1) UDP
+	// response: paired with the four-tuple the network analyzer already
+	// attaches to every message, it lets that retry be joined back to this
+	// response.
+	DnsTcpRetryId = "dns_tcp_retry_id"
+)